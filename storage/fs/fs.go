@@ -2,259 +2,70 @@
 package fs
 
 import (
-	"context"
-	"database/sql"
-	"errors"
-	"fmt"
+	"net/url"
+
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/rbastic/go-schemaless/models"
-	"go.uber.org/zap"
-	"time"
+	"github.com/rbastic/go-schemaless/storage/sqlbackend"
 )
 
-// Storage is a simple file-backed storage.
-type Storage struct {
-	store *sql.DB
-	sugar *zap.SugaredLogger
+// Config controls the SQLite connection pragmas applied to fs's
+// underlying cell database file. Pragma keys are the bare PRAGMA name,
+// e.g. "journal_mode", "synchronous", "busy_timeout", "cache_size",
+// "foreign_keys" - fs rewrites them into mattn/go-sqlite3's DSN query
+// parameters.
+type Config struct {
+	CellPragmas map[string]string
 }
 
-const (
-	driver = "sqlite3"
-
-	createTableSQL      = "CREATE TABLE cell ( added_at INTEGER PRIMARY KEY AUTOINCREMENT, row_key VARCHAR(36) NOT NULL, column_name VARCHAR(64) NOT NULL, ref_key INTEGER NOT NULL, body TEXT, created_at DATETIME DEFAULT (datetime('now','localtime')))"
-	createIndexSQL      = "CREATE UNIQUE INDEX IF NOT EXISTS uniqcell_idx ON cell ( row_key, column_name, ref_key )"
-	getCellSQL          = "SELECT added_at, row_key, column_name, ref_key, body,created_at FROM cell WHERE row_key = ? AND column_name = ? AND ref_key = ? LIMIT 1"
-	getCellLatestSQL    = "SELECT added_at, row_key, column_name, ref_key, body, created_at FROM cell WHERE row_key = ? AND column_name = ? ORDER BY ref_key DESC LIMIT 1"
-	getCellsForShardSQL = "SELECT added_at, row_key, column_name, ref_key, body, created_at FROM cell WHERE %s > ? LIMIT %d"
-	putCellSQL          = "INSERT INTO cell ( row_key, column_name, ref_key, body ) VALUES(?, ?, ?, ?)"
-)
-
-func exec(db *sql.DB, sqlStr string) error {
-	_, err := db.Exec(sqlStr)
-	if err != nil {
-		return err
+// DefaultConfig is the pragma set New uses: WAL journaling with normal
+// sync, a 5s busy timeout, a ~2MB page cache, and foreign keys on.
+func DefaultConfig() Config {
+	return Config{
+		CellPragmas: map[string]string{
+			"journal_mode": "WAL",
+			"synchronous":  "NORMAL",
+			"busy_timeout": "5000",
+			"cache_size":   "-2000",
+			"foreign_keys": "ON",
+		},
 	}
-	return nil
-}
-
-func createTable(ctx context.Context, db *sql.DB) error {
-	return exec(db, createTableSQL)
 }
 
-func createIndex(ctx context.Context, db *sql.DB) error {
-	return exec(db, createIndexSQL)
+// Storage is a simple SQLite file-backed storage: <path>_cell.db holds
+// the cell table via sqlbackend's sqlite3 dialect.
+//
+// go-schemaless doesn't define request-log/audit-log schemas yet, so fs
+// doesn't open <path>_log.db/<path>_meta.db files for them - an idle
+// *sql.DB plus its writer goroutine isn't worth holding open with nothing
+// to write. Add those files here once those tables, and something that
+// writes to them, actually exist.
+type Storage struct {
+	*sqlbackend.Storage
 }
 
-// New returns a new sqlite file-backed Storage
+// New returns a new sqlite file-backed Storage using DefaultConfig.
 func New(path string) *Storage {
-	db, err := sql.Open(driver, path+"_cell.db")
-	if err != nil {
-		panic(err)
-	}
-
-	err = createTable(context.TODO(), db)
-	if err != nil {
-		panic(err)
-	}
-
-	err = createIndex(context.TODO(), db)
-	if err != nil {
-		panic(err)
-	}
-
-	logger, err := zap.NewProduction()
-	if err != nil {
-		panic(err)
-	}
-	s := logger.Sugar()
-
-	return &Storage{
-		// initialize top-level
-		store: db,
-		sugar: s,
-	}
-}
-
-func (s *Storage) GetCell(ctx context.Context, rowKey string, columnKey string, refKey int64) (cell models.Cell, found bool, err error) {
-	var (
-		resAddedAt   int64
-		resRowKey    string
-		resColName   string
-		resRefKey    int64
-		resBody      string
-		resCreatedAt *time.Time
-		rows         *sql.Rows
-	)
-	s.sugar.Infow("GetCell", "query", getCellSQL, "rowKey", rowKey, "columnKey", columnKey, "refKey", refKey)
-	rows, err = s.store.Query(getCellSQL, rowKey, columnKey, refKey)
-	if err != nil {
-		return
-	}
-	defer rows.Close()
-
-	found = false
-	for rows.Next() {
-		err = rows.Scan(&resAddedAt, &resRowKey, &resColName, &resRefKey, &resBody, &resCreatedAt)
-		if err != nil {
-			return
-		}
-		s.sugar.Infow("GetCell scanned data", "AddedAt", resAddedAt, "RowKey", resRowKey, "ColName", resColName, "RefKey", resRefKey, "Body", resBody, "CreatedAt", resCreatedAt)
-
-		cell.AddedAt = resAddedAt
-		cell.RowKey = resRowKey
-		cell.ColumnName = resColName
-		cell.RefKey = resRefKey
-		cell.Body = resBody
-		cell.CreatedAt = resCreatedAt
-		found = true
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return
-	}
-
-	return cell, found, nil
-}
-
-func (s *Storage) GetCellLatest(ctx context.Context, rowKey, columnKey string) (cell models.Cell, found bool, err error) {
-	var (
-		resAddedAt   int64
-		resRowKey    string
-		resColName   string
-		resRefKey    int64
-		resBody      string
-		resCreatedAt *time.Time
-		rows         *sql.Rows
-	)
-	s.sugar.Infow("GetCellLatest", "query", getCellSQL, "rowKey", rowKey, "columnKey", columnKey)
-	rows, err = s.store.Query(getCellLatestSQL, rowKey, columnKey)
-	if err != nil {
-		return
-	}
-	defer rows.Close()
-
-	found = false
-	for rows.Next() {
-		err = rows.Scan(&resAddedAt, &resRowKey, &resColName, &resRefKey, &resBody, &resCreatedAt)
-		if err != nil {
-			return
-		}
-		s.sugar.Infow("GetCellLatest scanned data", "AddedAt", resAddedAt, "RowKey", resRowKey, "ColName", resColName, "RefKey", resRefKey, "Body", resBody, "CreatedAt", resCreatedAt)
-
-		cell.AddedAt = resAddedAt
-		cell.RowKey = resRowKey
-		cell.ColumnName = resColName
-		cell.RefKey = resRefKey
-		cell.Body = resBody
-		cell.CreatedAt = resCreatedAt
-		found = true
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return
-	}
-
-	return cell, found, nil
+	return NewWithConfig(path, DefaultConfig())
 }
 
-func (s *Storage) PartitionRead(ctx context.Context, partitionNumber int, location string, value interface{}, limit int) (cells []models.Cell, found bool, err error) {
-
-	var (
-		resAddedAt   int64
-		resRowKey    string
-		resColName   string
-		resRefKey    int64
-		resBody      string
-		resCreatedAt *time.Time
-
-		locationColumn string
-	)
-
-	switch location {
-	case "timestamp":
-		fallthrough
-	case "created_at":
-		locationColumn = "created_at"
-	case "added_at":
-		locationColumn = "added_at"
-	default:
-		err = errors.New("Unrecognized location " + location)
-		return
-	}
-
-	sqlStr := fmt.Sprintf(getCellsForShardSQL, locationColumn, limit)
-
-	var rows *sql.Rows
-	s.sugar.Infow("PartitionRead", "query", sqlStr, "value", value)
-	rows, err = s.store.Query(sqlStr, value)
+// NewWithConfig is New, but with caller-supplied cell pragmas.
+func NewWithConfig(path string, cfg Config) *Storage {
+	cell, err := sqlbackend.NewSQLite(dsn(path+"_cell.db", cfg.CellPragmas))
 	if err != nil {
-		return
-	}
-	defer rows.Close()
-
-	found = false
-	for rows.Next() {
-		err = rows.Scan(&resAddedAt, &resRowKey, &resColName, &resRefKey, &resBody, &resCreatedAt)
-		if err != nil {
-			return
-		}
-		s.sugar.Infow("PartitionRead: scanned data", "AddedAt", resAddedAt, "RowKey", resRowKey, "ColName", resColName, "RefKey", resRefKey, "Body", resBody, "CreatedAt", resCreatedAt)
-
-		var cell models.Cell
-		cell.AddedAt = resAddedAt
-		cell.RowKey = resRowKey
-		cell.ColumnName = resColName
-		cell.RefKey = resRefKey
-		cell.Body = resBody
-		cell.CreatedAt = resCreatedAt
-		cells = append(cells, cell)
-		found = true
-	}
-
-	err = rows.Err()
-	if err != nil {
-		return
+		panic(err)
 	}
 
-	return cells, found, nil
+	return &Storage{Storage: cell}
 }
 
-func (s *Storage) PutCell(ctx context.Context, rowKey, columnKey string, refKey int64, cell models.Cell) (err error) {
-	var stmt *sql.Stmt
-	stmt, err = s.store.Prepare(putCellSQL)
-	if err != nil {
-		return
-	}
-	var res sql.Result
-	s.sugar.Infow("PutCell", "rowKey", rowKey, "columnKey", columnKey, "refKey", refKey, "Body", cell.Body)
-	res, err = stmt.Exec(rowKey, columnKey, refKey, cell.Body)
-	if err != nil {
-		return
-	}
-	var lastID int64
-	lastID, err = res.LastInsertId()
-	if err != nil {
-		return
-	}
-	var rowCnt int64
-	rowCnt, err = res.RowsAffected()
-	if err != nil {
-		return
+// dsn rewrites pragmas into mattn/go-sqlite3's "_pragma=value" DSN query
+// parameters, plus "_txlock=immediate" so sqlutil.Writer's transactions
+// acquire the write lock eagerly.
+func dsn(path string, pragmas map[string]string) string {
+	values := url.Values{}
+	values.Set("_txlock", "immediate")
+	for k, v := range pragmas {
+		values.Set("_"+k, v)
 	}
-	// TODO(rbastic): Should we side-affect the cell and record the AddedAt?
-	s.sugar.Infof("ID = %d, affected = %d\n", lastID, rowCnt)
-	return
-}
-
-// ResetConnection does not destroy the store for in-memory stores.
-func (s *Storage) ResetConnection(ctx context.Context, key string) error {
-	return nil
-}
-
-// Destroy closes the in-memory store, and is a completely destructive operation.
-func (s *Storage) Destroy(ctx context.Context) error {
-	s.sugar.Sync()
-	return s.store.Close()
+	return path + "?" + values.Encode()
 }
@@ -0,0 +1,68 @@
+// Package sqlbackend is a driver-agnostic SQL storage implementation,
+// shared by the sqlite, postgres, and mysql backends. Reads and schema
+// sync go through a shared xorm.Engine; each backend's Dialect supplies
+// just what xorm doesn't cover - placeholder syntax for PutCell's raw SQL
+// and the write-path coordinator.
+package sqlbackend
+
+import (
+	"database/sql"
+	"strconv"
+
+	"github.com/rbastic/go-schemaless/storage/sqlutil"
+)
+
+// OnConflict controls how PutCells handles a row that collides with the
+// (row_key, column_name, ref_key) unique index.
+type OnConflict int
+
+const (
+	// OnConflictError lets the collision surface as a driver error.
+	OnConflictError OnConflict = iota
+	// OnConflictIgnore silently keeps the existing row.
+	OnConflictIgnore
+	// OnConflictReplace overwrites the existing row's body.
+	OnConflictReplace
+)
+
+// Dialect captures the per-flavor pieces Storage needs outside of xorm:
+// PutCell/PutCells' raw SQL, its placeholder syntax, and the write-path
+// coordinator. Table/index DDL isn't part of Dialect - engine.Sync2
+// against cellRow handles schema sync identically across all three
+// flavors.
+type Dialect interface {
+	// Name identifies the dialect for logging.
+	Name() string
+
+	PutCellSQL() string
+	// PutCellsSQL is PutCellSQL with onConflict's handling spliced in,
+	// e.g. "INSERT OR IGNORE" on SQLite or "ON CONFLICT ... DO NOTHING"
+	// on Postgres.
+	PutCellsSQL(onConflict OnConflict) string
+
+	// Rebind rewrites a query written with `?` placeholders into this
+	// dialect's native placeholder syntax (a no-op for sqlite/mysql).
+	Rebind(query string) string
+
+	// NewWriter returns the write-path coordinator for db: a serializing
+	// sqlutil.Writer for SQLite, or a sqlutil.NoopWriter for backends
+	// whose server already handles write concurrency.
+	NewWriter(db *sql.DB) sqlutil.Txer
+}
+
+// rebindDollar rewrites sequential `?` placeholders into Postgres-style
+// `$1`, `$2`, ... placeholders.
+func rebindDollar(query string) string {
+	var out []byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			out = append(out, query[i])
+			continue
+		}
+		n++
+		out = append(out, '$')
+		out = append(out, []byte(strconv.Itoa(n))...)
+	}
+	return string(out)
+}
@@ -0,0 +1,37 @@
+package sqlbackend
+
+import "testing"
+
+func TestRebindDollar(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"row_key = ?", "row_key = $1"},
+		{"row_key = ? AND column_name = ? AND ref_key = ?", "row_key = $1 AND column_name = $2 AND ref_key = $3"},
+	}
+	for _, c := range cases {
+		if got := rebindDollar(c.in); got != c.want {
+			t.Errorf("rebindDollar(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDialectPutCellsSQL(t *testing.T) {
+	dialects := []Dialect{SQLite{}, Postgres{}, MySQL{}}
+	conflicts := []OnConflict{OnConflictError, OnConflictIgnore, OnConflictReplace}
+
+	for _, d := range dialects {
+		seen := map[string]bool{}
+		for _, oc := range conflicts {
+			sql := d.PutCellsSQL(oc)
+			if sql == "" {
+				t.Errorf("%s.PutCellsSQL(%d) returned empty SQL", d.Name(), oc)
+			}
+			seen[sql] = true
+		}
+		if len(seen) != len(conflicts) {
+			t.Errorf("%s.PutCellsSQL should return distinct SQL per OnConflict policy, got %d distinct out of %d", d.Name(), len(seen), len(conflicts))
+		}
+	}
+}
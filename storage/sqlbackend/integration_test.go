@@ -0,0 +1,179 @@
+//go:build integration
+
+package sqlbackend
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rbastic/go-schemaless/models"
+)
+
+// putGetRoundTrip exercises PutCell/GetCell/GetCellLatest/PutCells
+// against a live Storage, regardless of which backend built it.
+func putGetRoundTrip(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	cell := models.Cell{Body: "hello"}
+	if err := s.PutCell(ctx, "row1", "col1", 1, cell); err != nil {
+		t.Fatalf("PutCell: %v", err)
+	}
+
+	got, found, err := s.GetCell(ctx, "row1", "col1", 1)
+	if err != nil {
+		t.Fatalf("GetCell: %v", err)
+	}
+	if !found {
+		t.Fatal("GetCell: cell not found")
+	}
+	if got.Body != "hello" {
+		t.Errorf("GetCell Body = %q, want %q", got.Body, "hello")
+	}
+	if got.CreatedAt == nil || got.CreatedAt.Unix() == 0 {
+		t.Errorf("GetCell CreatedAt = %v, want a populated timestamp", got.CreatedAt)
+	}
+
+	latest, found, err := s.GetCellLatest(ctx, "row1", "col1")
+	if err != nil {
+		t.Fatalf("GetCellLatest: %v", err)
+	}
+	if !found || latest.Body != "hello" {
+		t.Errorf("GetCellLatest = %+v, found=%v, want Body %q", latest, found, "hello")
+	}
+
+	batch := []models.Cell{
+		{RowKey: "row2", ColumnName: "col1", RefKey: 1, Body: "batch1"},
+		{RowKey: "row2", ColumnName: "col1", RefKey: 2, Body: "batch2"},
+	}
+	if err := s.PutCells(ctx, batch, OnConflictError); err != nil {
+		t.Fatalf("PutCells: %v", err)
+	}
+
+	got, found, err = s.GetCell(ctx, "row2", "col1", 2)
+	if err != nil {
+		t.Fatalf("GetCell after PutCells: %v", err)
+	}
+	if !found || got.Body != "batch2" {
+		t.Errorf("GetCell after PutCells = %+v, found=%v, want Body %q", got, found, "batch2")
+	}
+	if got.CreatedAt == nil || got.CreatedAt.Unix() == 0 {
+		t.Errorf("GetCell after PutCells CreatedAt = %v, want a populated timestamp", got.CreatedAt)
+	}
+}
+
+// onConflictRoundTrip inserts the same (row_key, column_name, ref_key) twice
+// under each OnConflict policy and checks both the resulting body and that
+// exactly one row survives - this is what the uniqcell_idx unique index
+// (see cellRow) exists to guarantee; without it, OnConflictIgnore/Replace's
+// SQL clauses have nothing to conflict against and silently insert a
+// second row instead.
+func onConflictRoundTrip(t *testing.T, s *Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	first := []models.Cell{{RowKey: "dup", ColumnName: "col1", RefKey: 1, Body: "first"}}
+	if err := s.PutCells(ctx, first, OnConflictError); err != nil {
+		t.Fatalf("PutCells initial insert: %v", err)
+	}
+
+	ignored := []models.Cell{{RowKey: "dup", ColumnName: "col1", RefKey: 1, Body: "second"}}
+	if err := s.PutCells(ctx, ignored, OnConflictIgnore); err != nil {
+		t.Fatalf("PutCells OnConflictIgnore: %v", err)
+	}
+	got, found, err := s.GetCell(ctx, "dup", "col1", 1)
+	if err != nil {
+		t.Fatalf("GetCell after OnConflictIgnore: %v", err)
+	}
+	if !found || got.Body != "first" {
+		t.Errorf("GetCell after OnConflictIgnore = %+v, found=%v, want Body %q (collision should be ignored)", got, found, "first")
+	}
+	assertRowCount(t, s, "dup", "col1", 1, 1)
+
+	replaced := []models.Cell{{RowKey: "dup", ColumnName: "col1", RefKey: 1, Body: "third"}}
+	if err := s.PutCells(ctx, replaced, OnConflictReplace); err != nil {
+		t.Fatalf("PutCells OnConflictReplace: %v", err)
+	}
+	got, found, err = s.GetCell(ctx, "dup", "col1", 1)
+	if err != nil {
+		t.Fatalf("GetCell after OnConflictReplace: %v", err)
+	}
+	if !found || got.Body != "third" {
+		t.Errorf("GetCell after OnConflictReplace = %+v, found=%v, want Body %q (collision should replace)", got, found, "third")
+	}
+	assertRowCount(t, s, "dup", "col1", 1, 1)
+}
+
+// assertRowCount scans cells via PartitionRead (the only bulk-read API
+// Storage exposes) and counts how many match the given key, since neither
+// GetCell nor GetCellLatest would surface a duplicate row on their own.
+func assertRowCount(t *testing.T, s *Storage, rowKey, columnName string, refKey int64, want int) {
+	t.Helper()
+	cells, _, err := s.PartitionRead(context.Background(), 0, "added_at", int64(0), 1000)
+	if err != nil {
+		t.Fatalf("PartitionRead: %v", err)
+	}
+
+	got := 0
+	for _, c := range cells {
+		if c.RowKey == rowKey && c.ColumnName == columnName && c.RefKey == refKey {
+			got++
+		}
+	}
+	if got != want {
+		t.Errorf("found %d rows for (%s, %s, %d), want %d", got, rowKey, columnName, refKey, want)
+	}
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cell.db")
+	s, err := NewSQLite(path + "?_txlock=immediate")
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	defer func() {
+		if err := s.Destroy(context.Background()); err != nil {
+			t.Errorf("Destroy: %v", err)
+		}
+	}()
+	putGetRoundTrip(t, s)
+	onConflictRoundTrip(t, s)
+}
+
+func TestPostgresRoundTrip(t *testing.T) {
+	dsn := os.Getenv("SCHEMALESS_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("SCHEMALESS_POSTGRES_DSN not set, skipping Postgres integration test")
+	}
+	s, err := NewPostgres(dsn)
+	if err != nil {
+		t.Fatalf("NewPostgres: %v", err)
+	}
+	defer func() {
+		if err := s.Destroy(context.Background()); err != nil {
+			t.Errorf("Destroy: %v", err)
+		}
+	}()
+	putGetRoundTrip(t, s)
+	onConflictRoundTrip(t, s)
+}
+
+func TestMySQLRoundTrip(t *testing.T) {
+	dsn := os.Getenv("SCHEMALESS_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("SCHEMALESS_MYSQL_DSN not set, skipping MySQL integration test")
+	}
+	s, err := NewMySQL(dsn)
+	if err != nil {
+		t.Fatalf("NewMySQL: %v", err)
+	}
+	defer func() {
+		if err := s.Destroy(context.Background()); err != nil {
+			t.Errorf("Destroy: %v", err)
+		}
+	}()
+	putGetRoundTrip(t, s)
+	onConflictRoundTrip(t, s)
+}
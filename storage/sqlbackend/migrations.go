@@ -0,0 +1,48 @@
+package sqlbackend
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// schemaVersion is bumped whenever cellRow's schema changes in a way that
+// needs tracking. migrate records it in migration_history once applied,
+// so New doesn't re-run sync on every open.
+const schemaVersion = 1
+
+// migrate creates migration_history if it doesn't exist yet, and - unless
+// schemaVersion is already recorded as applied - runs sync and records it.
+// sync is engine.Sync2(new(cellRow)); it's passed in rather than called
+// directly so migrate itself stays storage-agnostic and testable.
+func migrate(ctx context.Context, db *sql.DB, d Dialect, sync func() error) error {
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS migration_history ( version INTEGER PRIMARY KEY, applied_at INTEGER NOT NULL )"); err != nil {
+		return err
+	}
+
+	applied, err := isApplied(ctx, db, d, schemaVersion)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	if err := sync(); err != nil {
+		return err
+	}
+	return recordApplied(ctx, db, d, schemaVersion)
+}
+
+func isApplied(ctx context.Context, db *sql.DB, d Dialect, version int) (bool, error) {
+	var count int
+	query := d.Rebind("SELECT COUNT(*) FROM migration_history WHERE version = ?")
+	err := db.QueryRowContext(ctx, query, version).Scan(&count)
+	return count > 0, err
+}
+
+func recordApplied(ctx context.Context, db *sql.DB, d Dialect, version int) error {
+	query := d.Rebind("INSERT INTO migration_history ( version, applied_at ) VALUES(?, ?)")
+	_, err := db.ExecContext(ctx, query, version, time.Now().Unix())
+	return err
+}
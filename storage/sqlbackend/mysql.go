@@ -0,0 +1,38 @@
+package sqlbackend
+
+import (
+	"database/sql"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/rbastic/go-schemaless/storage/sqlutil"
+)
+
+const mysqlPutCellSQL = "INSERT INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?)"
+
+// MySQL implements Dialect for the go-sql-driver/mysql driver.
+type MySQL struct{}
+
+func (MySQL) Name() string               { return "mysql" }
+func (MySQL) PutCellSQL() string         { return mysqlPutCellSQL }
+func (MySQL) Rebind(query string) string { return query }
+
+func (MySQL) PutCellsSQL(onConflict OnConflict) string {
+	switch onConflict {
+	case OnConflictIgnore:
+		return "INSERT IGNORE INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?)"
+	case OnConflictReplace:
+		return "INSERT INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE body = VALUES(body), created_at = VALUES(created_at)"
+	default:
+		return mysqlPutCellSQL
+	}
+}
+
+// NewWriter returns a sqlutil.NoopWriter: InnoDB serializes concurrent
+// writers itself, so no client-side coordination is needed.
+func (MySQL) NewWriter(db *sql.DB) sqlutil.Txer { return sqlutil.NewNoop(db) }
+
+// NewMySQL returns a Storage backed by a MySQL/MariaDB cluster at dsn, e.g.
+// "user:pass@tcp(host:3306)/dbname?parseTime=true".
+func NewMySQL(dsn string) (*Storage, error) {
+	return New("mysql", dsn, MySQL{})
+}
@@ -0,0 +1,39 @@
+package sqlbackend
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/rbastic/go-schemaless/storage/sqlutil"
+)
+
+const sqlitePutCellSQL = "INSERT INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?)"
+
+// SQLite implements Dialect for the sqlite3 driver.
+type SQLite struct{}
+
+func (SQLite) Name() string               { return "sqlite3" }
+func (SQLite) PutCellSQL() string         { return sqlitePutCellSQL }
+func (SQLite) Rebind(query string) string { return query }
+
+func (SQLite) PutCellsSQL(onConflict OnConflict) string {
+	switch onConflict {
+	case OnConflictIgnore:
+		return "INSERT OR IGNORE INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?)"
+	case OnConflictReplace:
+		return "INSERT OR REPLACE INTO cell ( row_key, column_name, ref_key, body, created_at ) VALUES(?, ?, ?, ?, ?)"
+	default:
+		return sqlitePutCellSQL
+	}
+}
+
+// NewWriter returns a sqlutil.Writer, since SQLite only allows one writer
+// at a time and needs write calls serialized to avoid SQLITE_BUSY.
+func (SQLite) NewWriter(db *sql.DB) sqlutil.Txer { return sqlutil.New(db) }
+
+// NewSQLite returns a Storage backed by a local SQLite file at dsn. dsn
+// should include "_txlock=immediate" so the Writer's transactions acquire
+// the write lock eagerly; see sqlutil.Writer.
+func NewSQLite(dsn string) (*Storage, error) {
+	return New("sqlite3", dsn, SQLite{})
+}
@@ -0,0 +1,212 @@
+package sqlbackend
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-xorm/builder"
+	"github.com/go-xorm/xorm"
+	"github.com/rbastic/go-schemaless/models"
+	"github.com/rbastic/go-schemaless/storage/sqlutil"
+	"go.uber.org/zap"
+)
+
+// Storage is a dialect-driven SQL storage implementation shared by the
+// sqlite, postgres, and mysql backends. The SQL differences between
+// flavors live entirely behind Dialect. Reads and schema sync go through
+// an xorm.Engine mapped onto cellRow; PutCell/PutCells still issue raw,
+// dialect-specific SQL through the write-serializing layer below, since
+// that path needs a concrete *sql.Tx to retry against.
+type Storage struct {
+	engine  *xorm.Engine
+	dialect Dialect
+	writer  sqlutil.Txer
+	sugar   *zap.SugaredLogger
+
+	stmtMu    sync.Mutex
+	stmtCache map[string]*sql.Stmt
+}
+
+// New opens db via driverName/dsn, syncs the cellRow schema with
+// engine.Sync2 (which works identically across sqlite/postgres/mysql),
+// guarded by a migration_history table so Sync2 only runs once per
+// schemaVersion, and returns a ready-to-use Storage. PutCell goes through
+// the dialect's writer, which serializes it for SQLite and passes it
+// straight through for Postgres/MySQL; reads run directly through the
+// xorm engine.
+func New(driverName, dsn string, dialect Dialect) (*Storage, error) {
+	engine, err := xorm.NewEngine(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	sync := func() error { return engine.Sync2(new(cellRow)) }
+	if err := migrate(context.Background(), engine.DB().DB, dialect, sync); err != nil {
+		return nil, err
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Storage{
+		engine:    engine,
+		dialect:   dialect,
+		writer:    dialect.NewWriter(engine.DB().DB),
+		sugar:     logger.Sugar(),
+		stmtCache: make(map[string]*sql.Stmt),
+	}, nil
+}
+
+// preparedStmt returns a cached *sql.Stmt for query, preparing it against
+// the underlying *sql.DB once on first use instead of on every call.
+func (s *Storage) preparedStmt(ctx context.Context, query string) (*sql.Stmt, error) {
+	s.stmtMu.Lock()
+	defer s.stmtMu.Unlock()
+
+	if stmt, ok := s.stmtCache[query]; ok {
+		return stmt, nil
+	}
+
+	stmt, err := s.engine.DB().DB.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	s.stmtCache[query] = stmt
+	return stmt, nil
+}
+
+func (s *Storage) GetCell(ctx context.Context, rowKey string, columnKey string, refKey int64) (cell models.Cell, found bool, err error) {
+	var row cellRow
+	s.sugar.Infow("GetCell", "rowKey", rowKey, "columnKey", columnKey, "refKey", refKey)
+
+	found, err = s.engine.Context(ctx).Where("row_key = ? AND column_name = ? AND ref_key = ?", rowKey, columnKey, refKey).Get(&row)
+	if err != nil || !found {
+		return
+	}
+
+	s.sugar.Infow("GetCell scanned data", "row", row)
+	cell = toCell(row)
+	return
+}
+
+func (s *Storage) GetCellLatest(ctx context.Context, rowKey, columnKey string) (cell models.Cell, found bool, err error) {
+	var row cellRow
+	s.sugar.Infow("GetCellLatest", "rowKey", rowKey, "columnKey", columnKey)
+
+	found, err = s.engine.Context(ctx).Where("row_key = ? AND column_name = ?", rowKey, columnKey).OrderBy("ref_key DESC").Get(&row)
+	if err != nil || !found {
+		return
+	}
+
+	s.sugar.Infow("GetCellLatest scanned data", "row", row)
+	cell = toCell(row)
+	return
+}
+
+func (s *Storage) PartitionRead(ctx context.Context, partitionNumber int, location string, value interface{}, limit int) (cells []models.Cell, found bool, err error) {
+	var locationColumn string
+
+	switch location {
+	case "timestamp":
+		fallthrough
+	case "created_at":
+		locationColumn = "created_at"
+	case "added_at":
+		locationColumn = "added_at"
+	default:
+		err = errors.New("Unrecognized location " + location)
+		return
+	}
+
+	var rows []cellRow
+	s.sugar.Infow("PartitionRead", "column", locationColumn, "value", value, "limit", limit)
+
+	err = s.engine.Context(ctx).Where(builder.Gt{locationColumn: value}).Limit(limit).Find(&rows)
+	if err != nil {
+		return
+	}
+
+	for _, row := range rows {
+		s.sugar.Infow("PartitionRead: scanned data", "row", row)
+		cells = append(cells, toCell(row))
+	}
+	found = len(cells) > 0
+
+	return
+}
+
+func (s *Storage) PutCell(ctx context.Context, rowKey, columnKey string, refKey int64, cell models.Cell) error {
+	query := s.dialect.Rebind(s.dialect.PutCellSQL())
+	s.sugar.Infow("PutCell", "rowKey", rowKey, "columnKey", columnKey, "refKey", refKey, "Body", cell.Body)
+
+	return s.writer.Do(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		stmt, err := s.preparedStmt(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, rowKey, columnKey, refKey, cell.Body, time.Now().Unix())
+		if err != nil {
+			return err
+		}
+		// lib/pq doesn't implement LastInsertId for any Exec result, so we
+		// can't rely on it portably across dialects; RowsAffected is
+		// logged purely for diagnostics and isn't returned to the caller.
+		rowCnt, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		s.sugar.Infof("affected = %d\n", rowCnt)
+		return nil
+	})
+}
+
+// PutCells writes cells in a single transaction against one cached
+// prepared statement, instead of one PutCell round-trip per cell.
+// onConflict controls what happens when a cell collides with the
+// (row_key, column_name, ref_key) unique index.
+func (s *Storage) PutCells(ctx context.Context, cells []models.Cell, onConflict OnConflict) error {
+	query := s.dialect.Rebind(s.dialect.PutCellsSQL(onConflict))
+	s.sugar.Infow("PutCells", "count", len(cells), "onConflict", onConflict)
+
+	return s.writer.Do(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		stmt, err := s.preparedStmt(ctx, query)
+		if err != nil {
+			return err
+		}
+		txStmt := tx.StmtContext(ctx, stmt)
+		now := time.Now().Unix()
+
+		for _, cell := range cells {
+			if _, err := txStmt.ExecContext(ctx, cell.RowKey, cell.ColumnName, cell.RefKey, cell.Body, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResetConnection is a no-op; it does not destroy the store.
+func (s *Storage) ResetConnection(ctx context.Context, key string) error {
+	return nil
+}
+
+// Destroy closes the store, and is a completely destructive operation.
+func (s *Storage) Destroy(ctx context.Context) error {
+	s.sugar.Sync()
+
+	s.writer.Close()
+
+	s.stmtMu.Lock()
+	for _, stmt := range s.stmtCache {
+		stmt.Close()
+	}
+	s.stmtMu.Unlock()
+
+	return s.engine.Close()
+}
@@ -0,0 +1,48 @@
+package sqlbackend
+
+import (
+	"time"
+
+	"github.com/rbastic/go-schemaless/models"
+)
+
+// cellRow is the xorm-mapped row type for the cell table. models.Cell is
+// vendored from the upstream rbastic/go-schemaless module, so it can't
+// carry xorm struct tags directly; cellRow mirrors its fields plus the
+// CreatedUnix column, and is converted to/from models.Cell at the Storage
+// boundary.
+//
+// The "created" tag is xorm's auto-populate-on-Insert convention, but
+// PutCell/PutCells write through raw SQL (see storage.go) rather than
+// engine.Insert, since the write-serializing layer needs a concrete
+// *sql.Tx to retry against - so that convention never fires. Those SQL
+// statements set created_at themselves (time.Now().Unix()) instead.
+//
+// RowKey/ColumnName/RefKey share the "uniqcell_idx" unique tag so Sync2
+// creates the (row_key, column_name, ref_key) unique index - GetCell's
+// single-row read and
+// PutCells' OnConflict clauses both depend on that index existing.
+type cellRow struct {
+	AddedAt     int64  `xorm:"pk autoincr 'added_at'"`
+	RowKey      string `xorm:"varchar(36) notnull unique(uniqcell_idx) 'row_key'"`
+	ColumnName  string `xorm:"varchar(64) notnull unique(uniqcell_idx) 'column_name'"`
+	RefKey      int64  `xorm:"notnull unique(uniqcell_idx) 'ref_key'"`
+	Body        string `xorm:"TEXT 'body'"`
+	CreatedUnix int64  `xorm:"INDEX created 'created_at'"`
+}
+
+// TableName pins cellRow to the existing "cell" table rather than xorm's
+// default pluralized/snake_cased name.
+func (cellRow) TableName() string { return "cell" }
+
+func toCell(r cellRow) models.Cell {
+	createdAt := time.Unix(r.CreatedUnix, 0)
+	return models.Cell{
+		AddedAt:    r.AddedAt,
+		RowKey:     r.RowKey,
+		ColumnName: r.ColumnName,
+		RefKey:     r.RefKey,
+		Body:       r.Body,
+		CreatedAt:  &createdAt,
+	}
+}
@@ -0,0 +1,157 @@
+// Package sqlutil provides write-path concurrency helpers shared by the
+// sqlbackend storage implementations.
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Txer runs fn inside a single transaction and reports its error. Close
+// releases any background resources the Txer owns; a Txer must not be
+// used again after Close.
+type Txer interface {
+	Do(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error
+	Close()
+}
+
+const (
+	minBackoff  = 5 * time.Millisecond
+	maxBackoff  = 500 * time.Millisecond
+	maxAttempts = 10
+)
+
+// Writer serializes all writes against a single *sql.DB through one
+// goroutine, so that SQLite - which only allows one writer at a time -
+// returns a queued result instead of SQLITE_BUSY/SQLITE_LOCKED under
+// concurrent PutCell load. Reads are not routed through Writer; they keep
+// running directly and concurrently against the same *sql.DB.
+//
+// db's DSN must include "_txlock=immediate" (a mattn/go-sqlite3 option) so
+// that BeginTx issues BEGIN IMMEDIATE and acquires the write lock up
+// front, rather than SQLite's default deferred BEGIN which can still race
+// with other writers mid-transaction.
+type Writer struct {
+	db   *sql.DB
+	jobs chan job
+}
+
+type job struct {
+	ctx  context.Context
+	fn   func(ctx context.Context, tx *sql.Tx) error
+	errc chan error
+}
+
+// New starts a Writer goroutine that owns db's write path.
+func New(db *sql.DB) *Writer {
+	w := &Writer{
+		db:   db,
+		jobs: make(chan job),
+	}
+	go w.run()
+	return w
+}
+
+func (w *Writer) run() {
+	for j := range w.jobs {
+		j.errc <- w.doWithRetry(j.ctx, j.fn)
+	}
+}
+
+// Do queues fn to run against a single transaction on the Writer's
+// goroutine, retrying on SQLITE_BUSY/SQLITE_LOCKED with exponential
+// backoff, and blocks until it completes. A retry re-runs fn in full, not
+// just the Commit that failed - doOnce can't tell "fn failed" apart from
+// "fn succeeded but Commit then hit SQLITE_BUSY/LOCKED" - so fn must be
+// safe to execute more than once per Do call. Writes made through tx in a
+// failed attempt are not committed; effects fn has outside tx are repeated.
+func (w *Writer) Do(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	j := job{ctx: ctx, fn: fn, errc: make(chan error, 1)}
+	w.jobs <- j
+	return <-j.errc
+}
+
+// Close stops the Writer's goroutine by closing its job queue. Do must
+// not be called after Close.
+func (w *Writer) Close() {
+	close(w.jobs)
+}
+
+func (w *Writer) doWithRetry(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	backoff := minBackoff
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = w.doOnce(ctx, fn)
+		if err == nil || !isBusyOrLocked(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return err
+}
+
+func (w *Writer) doOnce(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isBusyOrLocked(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "SQLITE_BUSY")
+}
+
+// NoopWriter runs fn directly against db with no serialization or retry,
+// for backends (Postgres, MySQL) whose server already handles write
+// concurrency.
+type NoopWriter struct {
+	db *sql.DB
+}
+
+// NewNoop returns a NoopWriter over db.
+func NewNoop(db *sql.DB) *NoopWriter {
+	return &NoopWriter{db: db}
+}
+
+func (w *NoopWriter) Do(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close is a no-op: NoopWriter owns no background goroutine.
+func (w *NoopWriter) Close() {}
@@ -0,0 +1,71 @@
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// fakeConnector/fakeConn/fakeTx simulate a Commit that fails with
+// SQLITE_BUSY a fixed number of times before succeeding, so doWithRetry's
+// retry-after-commit-busy path can be exercised without real file
+// contention.
+type fakeConnector struct {
+	busyCommits int
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{connector: c}, nil
+}
+
+func (c *fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) { return nil, driver.ErrSkip }
+
+type fakeConn struct {
+	connector *fakeConnector
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return &fakeTx{connector: c.connector}, nil }
+
+type fakeTx struct {
+	connector *fakeConnector
+}
+
+func (tx *fakeTx) Commit() error {
+	if tx.connector.busyCommits > 0 {
+		tx.connector.busyCommits--
+		return sqlite3.Error{Code: sqlite3.ErrBusy}
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error { return nil }
+
+// TestWriterRetriesCommitBusy guards the behavior Do's doc comment warns
+// callers about: a commit-time SQLITE_BUSY/LOCKED retries fn in full, not
+// just the Commit, so fn must tolerate running more than once.
+func TestWriterRetriesCommitBusy(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{busyCommits: 2})
+	w := New(db)
+	defer w.Close()
+
+	var calls int
+	err := w.Do(context.Background(), func(ctx context.Context, tx *sql.Tx) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn ran %d times, want 3 (original attempt plus 2 commit-busy retries)", calls)
+	}
+}